@@ -0,0 +1,44 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package consumererror defines error types that consumers and exporters can
+// use to signal specific handling semantics up the pipeline.
+package consumererror
+
+import "errors"
+
+// Permanent wraps an error to indicate that it is permanent, i.e. retrying the
+// associated operation will not succeed no matter how many times it is attempted.
+// Callers that implement a retry loop (e.g. the exporterhelper queued-retry
+// sender) should check for this type and drop the data instead of re-enqueuing it.
+type Permanent struct {
+	Err error
+}
+
+var _ error = (*Permanent)(nil)
+
+// NewPermanent wraps an error to indicate it is permanent.
+func NewPermanent(err error) error {
+	return Permanent{Err: err}
+}
+
+func (p Permanent) Error() string {
+	return "Permanent error: " + p.Err.Error()
+}
+
+// IsPermanent returns true if err is (or wraps) a Permanent error.
+func IsPermanent(err error) bool {
+	var p Permanent
+	return errors.As(err, &p)
+}
@@ -0,0 +1,348 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+
+	"github.com/open-telemetry/opentelemetry-collector/component"
+	"github.com/open-telemetry/opentelemetry-collector/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-collector/exporter"
+)
+
+const (
+	// tracesExporterEnvVar names the environment variable AutoExporter reads to decide
+	// which registered trace exporter(s) to instantiate.
+	tracesExporterEnvVar = "OTEL_TRACES_EXPORTER"
+
+	// metricsExporterEnvVar names the environment variable AutoMetricsExporter reads to
+	// decide which registered metrics exporter(s) to instantiate.
+	metricsExporterEnvVar = "OTEL_METRICS_EXPORTER"
+
+	// noneExporterName, when present in an exporter env var, is skipped during
+	// resolution; other names in the same env var are still resolved. An env var naming
+	// only noneExporterName resolves no exporter, so Start returns an error.
+	noneExporterName = "none"
+)
+
+// TracePusherFactory builds a traceDataPusher (and its Shutdown, if any) for a
+// registered exporter name, given the static configuration AutoExporter was created
+// with.
+type TracePusherFactory func(cfg configmodels.Exporter) (traceDataPusher, Shutdown, error)
+
+// MetricsPusherFactory is the metricsDataPusher equivalent of TracePusherFactory.
+type MetricsPusherFactory func(cfg configmodels.Exporter) (metricsDataPusher, Shutdown, error)
+
+var (
+	tracePusherRegistryMu sync.Mutex
+	tracePusherRegistry   = make(map[string]TracePusherFactory)
+
+	metricsPusherRegistryMu sync.Mutex
+	metricsPusherRegistry   = make(map[string]MetricsPusherFactory)
+)
+
+// RegisterTracePusher registers a TracePusherFactory under name (e.g. "otlp",
+// "otlphttp", "jaeger", "zipkin", "logging") so AutoExporter can instantiate it from
+// OTEL_TRACES_EXPORTER. Exporter packages are expected to call this from their init().
+func RegisterTracePusher(name string, factory TracePusherFactory) {
+	tracePusherRegistryMu.Lock()
+	defer tracePusherRegistryMu.Unlock()
+	tracePusherRegistry[name] = factory
+}
+
+// RegisterMetricsPusher is the metricsDataPusher equivalent of RegisterTracePusher,
+// used by AutoMetricsExporter and OTEL_METRICS_EXPORTER.
+func RegisterMetricsPusher(name string, factory MetricsPusherFactory) {
+	metricsPusherRegistryMu.Lock()
+	defer metricsPusherRegistryMu.Unlock()
+	metricsPusherRegistry[name] = factory
+}
+
+// AutoExporter is a façade TraceExporter that, at Start time, reads
+// OTEL_TRACES_EXPORTER (comma-separated) and instantiates the matching
+// RegisterTracePusher factories, wired up through the regular NewTraceExporter (single
+// name) or NewSplitTraceExporter (multiple names) machinery. Names that are not
+// registered fall back to defaultName; if defaultName itself is not registered, that
+// name is skipped. This lets operators pick exporters via configuration alone instead
+// of wiring traceDataPushers explicitly, while explicit wiring through NewTraceExporter
+// remains fully supported.
+type AutoExporter struct {
+	config      configmodels.Exporter
+	defaultName string
+	options     []ExporterOption
+
+	mu       sync.Mutex
+	delegate exporter.TraceExporter
+}
+
+var _ exporter.TraceExporter = (*AutoExporter)(nil)
+
+// NewAutoExporter creates an AutoExporter that resolves traceDataPushers registered
+// under the names in OTEL_TRACES_EXPORTER, falling back to defaultName when a
+// requested name is not registered or the environment variable is unset.
+func NewAutoExporter(config configmodels.Exporter, defaultName string, options ...ExporterOption) *AutoExporter {
+	return &AutoExporter{config: config, defaultName: defaultName, options: options}
+}
+
+// Start resolves the configured exporter name(s) and builds the delegate exporter.
+func (ae *AutoExporter) Start(host component.Host) error {
+	pushers, shutdowns := resolveTracePushers(
+		exporterNamesFromEnv(tracesExporterEnvVar, ae.defaultName),
+		ae.defaultName,
+		ae.config,
+	)
+
+	if len(pushers) == 0 {
+		return errNoTracesExporterAvailable
+	}
+
+	opts := append(append([]ExporterOption(nil), ae.options...), WithShutdown(combineShutdown(shutdowns...)))
+
+	// Every exporter named in OTEL_TRACES_EXPORTER is expected to receive every batch
+	// (mirroring the OTel SDK's own comma-separated exporter env vars), so fan out with
+	// DuplicateRouter rather than sharding across destinations.
+	var delegate exporter.TraceExporter
+	var err error
+	if len(pushers) == 1 {
+		delegate, err = NewTraceExporter(ae.config, pushers[0], opts...)
+	} else {
+		delegate, err = NewSplitTraceExporter(ae.config, pushers, NewDuplicateRouter(len(pushers)), opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	ae.mu.Lock()
+	ae.delegate = delegate
+	ae.mu.Unlock()
+
+	return delegate.Start(host)
+}
+
+// ConsumeTraceData forwards to the delegate exporter built in Start.
+func (ae *AutoExporter) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	delegate := ae.currentDelegate()
+	if delegate == nil {
+		return errAutoExporterNotStarted
+	}
+	return delegate.ConsumeTraceData(ctx, td)
+}
+
+// Shutdown forwards to the delegate exporter built in Start, or does nothing if Start
+// never completed successfully.
+func (ae *AutoExporter) Shutdown() error {
+	delegate := ae.currentDelegate()
+	if delegate == nil {
+		return nil
+	}
+	return delegate.Shutdown()
+}
+
+func (ae *AutoExporter) currentDelegate() exporter.TraceExporter {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	return ae.delegate
+}
+
+// AutoMetricsExporter is the metricsDataPusher equivalent of AutoExporter, driven by
+// OTEL_METRICS_EXPORTER and RegisterMetricsPusher.
+type AutoMetricsExporter struct {
+	config      configmodels.Exporter
+	defaultName string
+	options     []ExporterOption
+
+	mu       sync.Mutex
+	delegate exporter.MetricsExporter
+}
+
+var _ exporter.MetricsExporter = (*AutoMetricsExporter)(nil)
+
+// NewAutoMetricsExporter creates an AutoMetricsExporter that resolves
+// metricsDataPushers registered under the names in OTEL_METRICS_EXPORTER, falling back
+// to defaultName when a requested name is not registered or the environment variable
+// is unset.
+func NewAutoMetricsExporter(config configmodels.Exporter, defaultName string, options ...ExporterOption) *AutoMetricsExporter {
+	return &AutoMetricsExporter{config: config, defaultName: defaultName, options: options}
+}
+
+// Start resolves the configured exporter name(s) and builds the delegate exporter.
+func (ae *AutoMetricsExporter) Start(host component.Host) error {
+	pushers, shutdowns := resolveMetricsPushers(
+		exporterNamesFromEnv(metricsExporterEnvVar, ae.defaultName),
+		ae.defaultName,
+		ae.config,
+	)
+
+	if len(pushers) == 0 {
+		return errNoMetricsExporterAvailable
+	}
+
+	// There is no metrics-capable split/fan-out sender yet (see NewSplitTraceExporter
+	// for the trace-side equivalent), so when more than one metrics exporter name is
+	// requested, fan out in-process: every resolved pusher receives every batch,
+	// mirroring how OTEL_TRACES_EXPORTER is handled above.
+	opts := append(append([]ExporterOption(nil), ae.options...), WithShutdown(combineShutdown(shutdowns...)))
+
+	metricsPusher := pushers[0]
+	if len(pushers) > 1 {
+		metricsPusher = fanOutMetricsPusher(pushers)
+	}
+
+	delegate, err := NewMetricsExporter(ae.config, metricsPusher, opts...)
+	if err != nil {
+		return err
+	}
+
+	ae.mu.Lock()
+	ae.delegate = delegate
+	ae.mu.Unlock()
+
+	return delegate.Start(host)
+}
+
+// ConsumeMetricsData forwards to the delegate exporter built in Start.
+func (ae *AutoMetricsExporter) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	delegate := ae.currentDelegate()
+	if delegate == nil {
+		return errAutoExporterNotStarted
+	}
+	return delegate.ConsumeMetricsData(ctx, md)
+}
+
+// Shutdown forwards to the delegate exporter built in Start, or does nothing if Start
+// never completed successfully.
+func (ae *AutoMetricsExporter) Shutdown() error {
+	delegate := ae.currentDelegate()
+	if delegate == nil {
+		return nil
+	}
+	return delegate.Shutdown()
+}
+
+func (ae *AutoMetricsExporter) currentDelegate() exporter.MetricsExporter {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	return ae.delegate
+}
+
+// fanOutMetricsPusher calls every pusher in pushers with the same batch, summing their
+// dropped-time-series counts and aggregating any errors with multierr.
+func fanOutMetricsPusher(pushers []metricsDataPusher) metricsDataPusher {
+	return func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		var totalDropped int
+		var errs error
+		for _, p := range pushers {
+			dropped, err := p(ctx, md)
+			totalDropped += dropped
+			if err != nil {
+				errs = multierr.Append(errs, err)
+			}
+		}
+		return totalDropped, errs
+	}
+}
+
+// resolveTracePushers builds a traceDataPusher and, when available, a Shutdown for
+// each name, falling back to defaultName for any name that is not registered. Names
+// that resolve to neither (including an unregistered defaultName) are skipped rather
+// than failing outright, so a partially-misconfigured env var still yields whatever
+// exporters are actually available.
+func resolveTracePushers(names []string, defaultName string, config configmodels.Exporter) ([]traceDataPusher, []Shutdown) {
+	tracePusherRegistryMu.Lock()
+	defer tracePusherRegistryMu.Unlock()
+
+	var pushers []traceDataPusher
+	var shutdowns []Shutdown
+	for _, name := range names {
+		if name == noneExporterName {
+			continue
+		}
+
+		factory, ok := tracePusherRegistry[name]
+		if !ok {
+			factory, ok = tracePusherRegistry[defaultName]
+			if !ok {
+				continue
+			}
+		}
+
+		pusher, shutdown, err := factory(config)
+		if err != nil || pusher == nil {
+			continue
+		}
+		pushers = append(pushers, pusher)
+		if shutdown != nil {
+			shutdowns = append(shutdowns, shutdown)
+		}
+	}
+	return pushers, shutdowns
+}
+
+// resolveMetricsPushers is the metricsDataPusher equivalent of resolveTracePushers.
+func resolveMetricsPushers(names []string, defaultName string, config configmodels.Exporter) ([]metricsDataPusher, []Shutdown) {
+	metricsPusherRegistryMu.Lock()
+	defer metricsPusherRegistryMu.Unlock()
+
+	var pushers []metricsDataPusher
+	var shutdowns []Shutdown
+	for _, name := range names {
+		if name == noneExporterName {
+			continue
+		}
+
+		factory, ok := metricsPusherRegistry[name]
+		if !ok {
+			factory, ok = metricsPusherRegistry[defaultName]
+			if !ok {
+				continue
+			}
+		}
+
+		pusher, shutdown, err := factory(config)
+		if err != nil || pusher == nil {
+			continue
+		}
+		pushers = append(pushers, pusher)
+		if shutdown != nil {
+			shutdowns = append(shutdowns, shutdown)
+		}
+	}
+	return pushers, shutdowns
+}
+
+func exporterNamesFromEnv(envVar, defaultName string) []string {
+	raw := os.Getenv(envVar)
+	if raw == "" {
+		return []string{defaultName}
+	}
+
+	var names []string
+	for _, n := range strings.Split(raw, ",") {
+		n = strings.TrimSpace(n)
+		if n != "" {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		return []string{defaultName}
+	}
+	return names
+}
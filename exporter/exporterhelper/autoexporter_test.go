@@ -0,0 +1,161 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-collector/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumerdata"
+)
+
+func withEnv(t *testing.T, key, value string) {
+	t.Helper()
+	old, had := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatalf("os.Setenv(%q) error = %v", key, err)
+	}
+	t.Cleanup(func() {
+		if had {
+			_ = os.Setenv(key, old)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+func TestExporterNamesFromEnv_Unset(t *testing.T) {
+	_ = os.Unsetenv("OTEL_TRACES_EXPORTER_TEST_UNSET")
+
+	got := exporterNamesFromEnv("OTEL_TRACES_EXPORTER_TEST_UNSET", "otlp")
+	if want := []string{"otlp"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("exporterNamesFromEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestExporterNamesFromEnv_CommaSeparatedTrimmed(t *testing.T) {
+	withEnv(t, "OTEL_TRACES_EXPORTER_TEST_CSV", " otlp , logging ,zipkin")
+
+	got := exporterNamesFromEnv("OTEL_TRACES_EXPORTER_TEST_CSV", "otlp")
+	want := []string{"otlp", "logging", "zipkin"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exporterNamesFromEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestExporterNamesFromEnv_BlankFallsBackToDefault(t *testing.T) {
+	withEnv(t, "OTEL_TRACES_EXPORTER_TEST_BLANK", "  ,  ,")
+
+	got := exporterNamesFromEnv("OTEL_TRACES_EXPORTER_TEST_BLANK", "otlp")
+	if want := []string{"otlp"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("exporterNamesFromEnv() = %v, want %v", got, want)
+	}
+}
+
+func fakeTracePusherFactory(calls *int) TracePusherFactory {
+	return func(cfg configmodels.Exporter) (traceDataPusher, Shutdown, error) {
+		return func(ctx context.Context, td consumerdata.TraceData) (int, error) {
+			*calls++
+			return 0, nil
+		}, nil, nil
+	}
+}
+
+func fakeMetricsPusherFactory(calls *int) MetricsPusherFactory {
+	return func(cfg configmodels.Exporter) (metricsDataPusher, Shutdown, error) {
+		return func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+			*calls++
+			return 0, nil
+		}, nil, nil
+	}
+}
+
+func TestResolveTracePushers_UnregisteredNameFallsBackToDefault(t *testing.T) {
+	var defaultCalls int
+	RegisterTracePusher("fallback-default-for-test", fakeTracePusherFactory(&defaultCalls))
+
+	pushers, _ := resolveTracePushers(
+		[]string{"not-registered-anywhere"},
+		"fallback-default-for-test",
+		fakeExporterConfig("fake"),
+	)
+	if len(pushers) != 1 {
+		t.Fatalf("len(pushers) = %d, want 1 (resolved via the default name)", len(pushers))
+	}
+	if _, err := pushers[0](context.Background(), consumerdata.TraceData{}); err != nil {
+		t.Fatalf("pusher() error = %v", err)
+	}
+	if defaultCalls != 1 {
+		t.Errorf("defaultCalls = %d, want 1", defaultCalls)
+	}
+}
+
+func TestResolveTracePushers_NoneSkipsEntirely(t *testing.T) {
+	pushers, shutdowns := resolveTracePushers([]string{"none"}, "unregistered-default", fakeExporterConfig("fake"))
+	if len(pushers) != 0 || len(shutdowns) != 0 {
+		t.Errorf("pushers = %v, shutdowns = %v, want both empty for \"none\"", pushers, shutdowns)
+	}
+}
+
+func TestAutoExporter_Start_MultipleNamesDuplicatesToEveryDestination(t *testing.T) {
+	var callsA, callsB int
+	RegisterTracePusher("fake-dup-a", fakeTracePusherFactory(&callsA))
+	RegisterTracePusher("fake-dup-b", fakeTracePusherFactory(&callsB))
+	withEnv(t, "OTEL_TRACES_EXPORTER", "fake-dup-a,fake-dup-b")
+
+	ae := NewAutoExporter(fakeExporterConfig("fake"), "fake-dup-a")
+	if err := ae.Start(nil); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if err := ae.ConsumeTraceData(context.Background(), consumerdata.TraceData{}); err != nil {
+		t.Fatalf("ConsumeTraceData() error = %v", err)
+	}
+
+	// Every exporter named in OTEL_TRACES_EXPORTER must receive every batch; a sharding
+	// router (e.g. round-robin) would leave one of these at 0.
+	if callsA != 1 {
+		t.Errorf("callsA = %d, want 1", callsA)
+	}
+	if callsB != 1 {
+		t.Errorf("callsB = %d, want 1", callsB)
+	}
+}
+
+func TestAutoMetricsExporter_Start_MultipleNamesFansOutToAll(t *testing.T) {
+	var callsA, callsB int
+	RegisterMetricsPusher("fake-metrics-dup-a", fakeMetricsPusherFactory(&callsA))
+	RegisterMetricsPusher("fake-metrics-dup-b", fakeMetricsPusherFactory(&callsB))
+	withEnv(t, "OTEL_METRICS_EXPORTER", "fake-metrics-dup-a,fake-metrics-dup-b")
+
+	ae := NewAutoMetricsExporter(fakeExporterConfig("fake"), "fake-metrics-dup-a")
+	if err := ae.Start(nil); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if err := ae.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{}); err != nil {
+		t.Fatalf("ConsumeMetricsData() error = %v", err)
+	}
+
+	if callsA != 1 {
+		t.Errorf("callsA = %d, want 1", callsA)
+	}
+	if callsB != 1 {
+		t.Errorf("callsB = %d, want 1", callsB)
+	}
+}
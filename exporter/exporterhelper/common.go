@@ -0,0 +1,95 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import "errors"
+
+var (
+	// errNilConfig is returned when an exporter helper constructor is called with a nil config.
+	errNilConfig = errors.New("nil config")
+
+	// errNilPushTraceData is returned when an exporter helper constructor is called with a nil traceDataPusher.
+	errNilPushTraceData = errors.New("nil traceDataPusher")
+
+	// errNilPushMetricsData is returned when an exporter helper constructor is called with a nil metricsDataPusher.
+	errNilPushMetricsData = errors.New("nil metricsDataPusher")
+
+	// errNoPushers is returned when a split exporter is constructed with no destination pushers.
+	errNoPushers = errors.New("no destination pushers")
+
+	// errNilSplitRouter is returned when a split exporter is constructed with a nil SplitRouter.
+	errNilSplitRouter = errors.New("nil SplitRouter")
+
+	// errNoTracesExporterAvailable is returned by AutoExporter.Start when none of the
+	// requested (or default) trace exporter names are registered.
+	errNoTracesExporterAvailable = errors.New("no registered trace exporter available")
+
+	// errNoMetricsExporterAvailable is returned by AutoMetricsExporter.Start when none
+	// of the requested (or default) metrics exporter names are registered.
+	errNoMetricsExporterAvailable = errors.New("no registered metrics exporter available")
+
+	// errAutoExporterNotStarted is returned when an AutoExporter/AutoMetricsExporter is
+	// used to consume data before Start has run successfully.
+	errAutoExporterNotStarted = errors.New("auto exporter used before Start")
+)
+
+// Shutdown is a function that is invoked during shutdown of an exporter built with this helper.
+type Shutdown func() error
+
+// ExporterOption apply changes to exporterOptions.
+type ExporterOption func(*exporterOptions)
+
+// exporterOptions contains options concerning how an exporter built with this helper behaves.
+type exporterOptions struct {
+	shutdown      Shutdown
+	retrySettings RetrySettings
+	queueSettings QueueSettings
+}
+
+// WithShutdown overrides the default Shutdown function for an exporter built with this helper.
+// The default shutdown function does nothing and always returns nil.
+func WithShutdown(shutdown Shutdown) ExporterOption {
+	return func(o *exporterOptions) {
+		o.shutdown = shutdown
+	}
+}
+
+func newExporterOptions(options ...ExporterOption) *exporterOptions {
+	opts := &exporterOptions{
+		retrySettings: DefaultRetrySettings(),
+		queueSettings: DefaultQueueSettings(),
+	}
+	for _, op := range options {
+		op(opts)
+	}
+	return opts
+}
+
+// combineShutdown returns a Shutdown that calls each of fns in order, continuing past
+// the first error (if any) and returning it once all fns have run.
+func combineShutdown(fns ...Shutdown) Shutdown {
+	return func() error {
+		var firstErr error
+		for _, fn := range fns {
+			if fn == nil {
+				continue
+			}
+			if err := fn(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	mQueueLength                = stats.Int64("exporter/queue_length", "current number of batches in the sending queue", stats.UnitDimensionless)
+	mRetryCount                 = stats.Int64("exporter/retry_count", "number of times a batch was retried after a transient error", stats.UnitDimensionless)
+	mPartialSuccessDroppedSpans = stats.Int64("exporter/partial_success_dropped_spans", "number of spans dropped due to a partial success response from the destination", stats.UnitDimensionless)
+)
+
+// exporterDestinationTagKey tags stats with the destination index they belong to, for
+// exporters (e.g. NewSplitTraceExporter) that fan the same exporter name out to several
+// destination pushers. It is inserted into the context passed to a destination's pusher
+// chain rather than folded into the exporter name itself, so the "exporter" tag obsreport
+// records keeps its original identity and cardinality.
+var exporterDestinationTagKey = tag.MustNewKey("exporter_destination")
+
+// MetricViews returns the opencensus views for the queued-retry sender metrics. Callers
+// (typically the service's telemetry initialization) are expected to register these.
+func MetricViews() []*view.View {
+	return []*view.View{
+		{
+			Name:        mQueueLength.Name(),
+			Description: mQueueLength.Description(),
+			Measure:     mQueueLength,
+			Aggregation: view.LastValue(),
+		},
+		{
+			Name:        mRetryCount.Name(),
+			Description: mRetryCount.Description(),
+			Measure:     mRetryCount,
+			TagKeys:     []tag.Key{exporterDestinationTagKey},
+			Aggregation: view.Count(),
+		},
+		{
+			Name:        mPartialSuccessDroppedSpans.Name(),
+			Description: mPartialSuccessDroppedSpans.Description(),
+			Measure:     mPartialSuccessDroppedSpans,
+			TagKeys:     []tag.Key{exporterDestinationTagKey},
+			Aggregation: view.Sum(),
+		},
+	}
+}
+
+func recordQueueLength(length int64) {
+	stats.Record(context.Background(), mQueueLength.M(length))
+}
+
+// recordRetryCount records a retry against ctx so that, when ctx carries an
+// exporterDestinationTagKey tag (e.g. from a split/fan-out exporter), the retry count is
+// broken out per destination rather than collapsed into a single series.
+func recordRetryCount(ctx context.Context, count int64) {
+	stats.Record(ctx, mRetryCount.M(count))
+}
+
+// recordPartialSuccessDroppedSpans records dropped spans against ctx; see
+// recordRetryCount for why ctx (rather than context.Background()) is threaded through.
+func recordPartialSuccessDroppedSpans(ctx context.Context, count int64) {
+	stats.Record(ctx, mPartialSuccessDroppedSpans.M(count))
+}
@@ -0,0 +1,190 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+
+	"github.com/open-telemetry/opentelemetry-collector/component"
+	"github.com/open-telemetry/opentelemetry-collector/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-collector/exporter"
+	"github.com/open-telemetry/opentelemetry-collector/obsreport"
+)
+
+// metricsDataPusher is a helper function that is similar to ConsumeMetricsData but also
+// returns the number of dropped time series.
+type metricsDataPusher func(ctx context.Context, md consumerdata.MetricsData) (droppedTimeSeries int, err error)
+
+// otlpMetricsDataPusher is a helper function that is similar to ConsumeMetricsData but
+// also returns the number of dropped time series.
+type otlpMetricsDataPusher func(ctx context.Context, md consumerdata.OTLPMetricsData) (droppedTimeSeries int, err error)
+
+// metricsExporter implements the exporter with additional helper options.
+type metricsExporter struct {
+	exporterFullName string
+	dataPusher       metricsDataPusher
+	shutdown         Shutdown
+}
+
+var _ exporter.MetricsExporter = (*metricsExporter)(nil)
+
+func (me *metricsExporter) Start(host component.Host) error {
+	return nil
+}
+
+func (me *metricsExporter) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	exporterCtx := obsreport.ExporterContext(ctx, me.exporterFullName)
+	_, err := me.dataPusher(exporterCtx, md)
+	return err
+}
+
+// Shutdown stops the exporter and is invoked during shutdown.
+func (me *metricsExporter) Shutdown() error {
+	return me.shutdown()
+}
+
+// NewMetricsExporter creates a MetricsExporter that can record metrics and can wrap
+// every request with a Span. If no options are passed it just adds the exporter format
+// as a tag in the Context.
+func NewMetricsExporter(
+	config configmodels.Exporter,
+	dataPusher metricsDataPusher,
+	options ...ExporterOption,
+) (exporter.MetricsExporter, error) {
+
+	if config == nil {
+		return nil, errNilConfig
+	}
+
+	if dataPusher == nil {
+		return nil, errNilPushMetricsData
+	}
+
+	opts := newExporterOptions(options...)
+
+	dataPusher = dataPusher.withObservability(config.Name())
+	dataPusher = newRetryMetricsSender(opts.retrySettings, dataPusher)
+	dataPusher, queueShutdown := newQueuedMetricsSender(config.Name(), opts.queueSettings, dataPusher)
+
+	// The default shutdown function does nothing.
+	shutdown := opts.shutdown
+	if shutdown == nil {
+		shutdown = func() error {
+			return nil
+		}
+	}
+	if queueShutdown != nil {
+		shutdown = combineShutdown(queueShutdown, shutdown)
+	}
+
+	return &metricsExporter{
+		exporterFullName: config.Name(),
+		dataPusher:       dataPusher,
+		shutdown:         shutdown,
+	}, nil
+}
+
+// withObservability wraps the current pusher into a function that records the
+// observability signals during the pusher execution.
+func (p metricsDataPusher) withObservability(exporterName string) metricsDataPusher {
+	return func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		exporterCtx, span := obsreport.StartMetricsExportOp(ctx, exporterName)
+		droppedTimeSeries, err := p(exporterCtx, md)
+
+		_, numTimeSeries := obsreport.CountMetricPoints(md)
+		obsreport.EndMetricsExportOp(exporterCtx, span, numTimeSeries, droppedTimeSeries, err)
+		return droppedTimeSeries, err
+	}
+}
+
+type otlpMetricsExporter struct {
+	exporterFullName string
+	dataPusher       otlpMetricsDataPusher
+	shutdown         Shutdown
+}
+
+var _ exporter.OTLPMetricsExporter = (*otlpMetricsExporter)(nil)
+
+func (me *otlpMetricsExporter) Start(host component.Host) error {
+	return nil
+}
+
+func (me *otlpMetricsExporter) ConsumeOTLPMetrics(
+	ctx context.Context,
+	md consumerdata.OTLPMetricsData,
+) error {
+	exporterCtx := obsreport.ExporterContext(ctx, me.exporterFullName)
+	_, err := me.dataPusher(exporterCtx, md)
+	return err
+}
+
+// Shutdown stops the exporter and is invoked during shutdown.
+func (me *otlpMetricsExporter) Shutdown() error {
+	return me.shutdown()
+}
+
+// NewOTLPMetricsExporter creates an OTLPMetricsExporter that can record metrics and can
+// wrap every request with a Span.
+func NewOTLPMetricsExporter(
+	config configmodels.Exporter,
+	dataPusher otlpMetricsDataPusher,
+	options ...ExporterOption,
+) (exporter.OTLPMetricsExporter, error) {
+
+	if config == nil {
+		return nil, errNilConfig
+	}
+
+	if dataPusher == nil {
+		return nil, errNilPushMetricsData
+	}
+
+	opts := newExporterOptions(options...)
+
+	dataPusher = dataPusher.withObservability(config.Name())
+	dataPusher = newRetryOTLPMetricsSender(opts.retrySettings, dataPusher)
+	dataPusher, queueShutdown := newQueuedOTLPMetricsSender(config.Name(), opts.queueSettings, dataPusher)
+
+	// The default shutdown function does nothing.
+	shutdown := opts.shutdown
+	if shutdown == nil {
+		shutdown = func() error {
+			return nil
+		}
+	}
+	if queueShutdown != nil {
+		shutdown = combineShutdown(queueShutdown, shutdown)
+	}
+
+	return &otlpMetricsExporter{
+		exporterFullName: config.Name(),
+		dataPusher:       dataPusher,
+		shutdown:         shutdown,
+	}, nil
+}
+
+// withObservability wraps the current pusher into a function that records the
+// observability signals during the pusher execution.
+func (p otlpMetricsDataPusher) withObservability(exporterName string) otlpMetricsDataPusher {
+	return func(ctx context.Context, md consumerdata.OTLPMetricsData) (int, error) {
+		exporterCtx, span := obsreport.StartMetricsExportOp(ctx, exporterName)
+		droppedTimeSeries, err := p(exporterCtx, md)
+
+		numTimeSeries := md.MetricsCount()
+		obsreport.EndMetricsExportOp(exporterCtx, span, numTimeSeries, droppedTimeSeries, err)
+		return droppedTimeSeries, err
+	}
+}
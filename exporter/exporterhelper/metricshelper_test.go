@@ -0,0 +1,108 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-collector/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumerdata"
+)
+
+func fakeExporterConfig(name string) configmodels.Exporter {
+	return &configmodels.ExporterSettings{TypeVal: name, NameVal: name}
+}
+
+func TestNewMetricsExporter_NilConfig(t *testing.T) {
+	_, err := NewMetricsExporter(nil, func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		return 0, nil
+	})
+	if !errors.Is(err, errNilConfig) {
+		t.Errorf("err = %v, want errNilConfig", err)
+	}
+}
+
+func TestNewMetricsExporter_NilPusher(t *testing.T) {
+	_, err := NewMetricsExporter(fakeExporterConfig("fake"), nil)
+	if !errors.Is(err, errNilPushMetricsData) {
+		t.Errorf("err = %v, want errNilPushMetricsData", err)
+	}
+}
+
+func TestNewMetricsExporter_ConsumeMetricsData(t *testing.T) {
+	var calls int
+	exp, err := NewMetricsExporter(fakeExporterConfig("fake"), func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		calls++
+		return 0, nil
+	})
+	if err != nil {
+		t.Fatalf("NewMetricsExporter() error = %v", err)
+	}
+
+	if err := exp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{}); err != nil {
+		t.Fatalf("ConsumeMetricsData() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("pusher calls = %d, want 1", calls)
+	}
+}
+
+// TestNewMetricsExporter_IntegratesWithLoggingStylePusher exercises NewMetricsExporter
+// through the same seam a real exporter package uses: a RegisterMetricsPusher factory
+// plugged into AutoMetricsExporter. Neither a logging nor an OTLP metrics exporter
+// package exists in this repository slice to import directly, so the pusher here stands
+// in for one, logging each MetricsData batch it receives the way a real logging exporter
+// would.
+func TestNewMetricsExporter_IntegratesWithLoggingStylePusher(t *testing.T) {
+	var logged []consumerdata.MetricsData
+	RegisterMetricsPusher("logging-style-stand-in", func(cfg configmodels.Exporter) (metricsDataPusher, Shutdown, error) {
+		return func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+			logged = append(logged, md)
+			return 0, nil
+		}, nil, nil
+	})
+
+	withEnv(t, "OTEL_METRICS_EXPORTER", "logging-style-stand-in")
+
+	ae := NewAutoMetricsExporter(fakeExporterConfig("fake"), "logging-style-stand-in")
+	if err := ae.Start(nil); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	md := consumerdata.MetricsData{}
+	if err := ae.ConsumeMetricsData(context.Background(), md); err != nil {
+		t.Fatalf("ConsumeMetricsData() error = %v", err)
+	}
+
+	if len(logged) != 1 {
+		t.Fatalf("logged batches = %d, want 1", len(logged))
+	}
+}
+
+func TestNewMetricsExporter_ConsumeMetricsDataPropagatesPusherError(t *testing.T) {
+	pusherErr := errors.New("destination unavailable")
+	exp, err := NewMetricsExporter(fakeExporterConfig("fake"), func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		return len(md.Metrics), pusherErr
+	})
+	if err != nil {
+		t.Fatalf("NewMetricsExporter() error = %v", err)
+	}
+
+	if err := exp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{}); !errors.Is(err, pusherErr) {
+		t.Errorf("ConsumeMetricsData() error = %v, want pusherErr", err)
+	}
+}
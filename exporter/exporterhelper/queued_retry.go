@@ -0,0 +1,387 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumererror"
+	"github.com/open-telemetry/opentelemetry-collector/obsreport"
+)
+
+// errQueueFull is returned by the queued sender when the in-memory queue is at capacity.
+var errQueueFull = errors.New("sending queue is full")
+
+// RetrySettings defines configuration for retrying batches that failed to be exported.
+// The only strategy currently supported is exponential backoff with jitter.
+type RetrySettings struct {
+	// Enabled indicates whether to retry batches in case of export failure.
+	Enabled bool
+	// InitialInterval is the time to wait after the first failure before retrying.
+	InitialInterval time.Duration
+	// MaxInterval is the upper bound on backoff interval.
+	MaxInterval time.Duration
+	// MaxElapsedTime is the maximum amount of time spent trying to send a batch before giving up.
+	// Once this value is reached the data is discarded.
+	MaxElapsedTime time.Duration
+	// Multiplier is the value the current backoff interval is multiplied by on each
+	// successive retry.
+	Multiplier float64
+}
+
+// DefaultRetrySettings returns the default settings for RetrySettings.
+func DefaultRetrySettings() RetrySettings {
+	return RetrySettings{
+		Enabled:         false,
+		InitialInterval: 5 * time.Second,
+		MaxInterval:     30 * time.Second,
+		MaxElapsedTime:  5 * time.Minute,
+		Multiplier:      1.5,
+	}
+}
+
+// WithRetry wraps the exporter's dataPusher with a retry sender that retries transient
+// failures using exponential backoff. Errors that are (or wrap) consumererror.Permanent
+// are never retried.
+func WithRetry(retrySettings RetrySettings) ExporterOption {
+	return func(o *exporterOptions) {
+		o.retrySettings = retrySettings
+	}
+}
+
+// QueueSettings defines configuration for the bounded in-memory queue that sits in front
+// of the retry sender.
+type QueueSettings struct {
+	// Enabled indicates whether to queue batches before sending them to the next sender.
+	Enabled bool
+	// NumConsumers is the number of goroutines draining the queue.
+	NumConsumers int
+	// QueueSize is the maximum number of batches allowed in the queue at a given time.
+	QueueSize int
+}
+
+// DefaultQueueSettings returns the default settings for QueueSettings.
+func DefaultQueueSettings() QueueSettings {
+	return QueueSettings{
+		Enabled:      false,
+		NumConsumers: 10,
+		QueueSize:    5000,
+	}
+}
+
+// WithQueue wraps the exporter's dataPusher with a bounded queue sender according to the
+// given settings.
+func WithQueue(queueSettings QueueSettings) ExporterOption {
+	return func(o *exporterOptions) {
+		o.queueSettings = queueSettings
+	}
+}
+
+func (rs RetrySettings) newBackOff() *backoff.ExponentialBackOff {
+	eb := backoff.NewExponentialBackOff()
+	eb.InitialInterval = rs.InitialInterval
+	eb.MaxInterval = rs.MaxInterval
+	eb.MaxElapsedTime = rs.MaxElapsedTime
+	eb.Multiplier = rs.Multiplier
+	// NewExponentialBackOff already calls Reset, but it seeds currentInterval from
+	// InitialInterval *before* we overwrite that field above, so we must Reset again
+	// now that the caller's settings are in place.
+	eb.Reset()
+	return eb
+}
+
+// retryingSend calls send, retrying according to retrySettings until it succeeds, the
+// error is permanent, the backoff is exhausted, or ctx is done.
+func retryingSend(ctx context.Context, retrySettings RetrySettings, send func() (int, error)) (int, error) {
+	if !retrySettings.Enabled {
+		return send()
+	}
+
+	expBackoff := retrySettings.newBackOff()
+	for {
+		dropped, err := send()
+		if err == nil {
+			return dropped, nil
+		}
+		if consumererror.IsPermanent(err) {
+			return dropped, err
+		}
+
+		backoffDelay := expBackoff.NextBackOff()
+		if backoffDelay == backoff.Stop {
+			return dropped, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return dropped, ctx.Err()
+		case <-time.After(backoffDelay):
+			recordRetryCount(ctx, 1)
+		}
+	}
+}
+
+// newRetryTraceSender wraps next with retryingSend, or returns next unchanged if retries
+// are disabled.
+func newRetryTraceSender(retrySettings RetrySettings, next traceDataPusher) traceDataPusher {
+	if !retrySettings.Enabled {
+		return next
+	}
+	return func(ctx context.Context, td consumerdata.TraceData) (int, error) {
+		return retryingSend(ctx, retrySettings, func() (int, error) {
+			return next(ctx, td)
+		})
+	}
+}
+
+// newRetryOTLPTraceSender is the otlpTraceDataPusher equivalent of newRetryTraceSender.
+func newRetryOTLPTraceSender(retrySettings RetrySettings, next otlpTraceDataPusher) otlpTraceDataPusher {
+	if !retrySettings.Enabled {
+		return next
+	}
+	return func(ctx context.Context, td consumerdata.OTLPTraceData) (int, error) {
+		return retryingSend(ctx, retrySettings, func() (int, error) {
+			return next(ctx, td)
+		})
+	}
+}
+
+// queuedSender is a bounded work queue drained by a fixed pool of consumer goroutines.
+// It is shared by the trace and OTLP-trace queue wrappers below.
+type queuedSender struct {
+	queue    chan func()
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+func startQueuedSender(queueSettings QueueSettings) *queuedSender {
+	qs := &queuedSender{
+		queue:  make(chan func(), queueSettings.QueueSize),
+		stopCh: make(chan struct{}),
+	}
+	for i := 0; i < queueSettings.NumConsumers; i++ {
+		qs.wg.Add(1)
+		go qs.runConsumer()
+	}
+	return qs
+}
+
+func (qs *queuedSender) runConsumer() {
+	defer qs.wg.Done()
+	for task := range qs.queue {
+		task()
+		recordQueueLength(int64(len(qs.queue)))
+	}
+}
+
+// enqueue tries to add task to the queue, returning errQueueFull if it is at capacity.
+func (qs *queuedSender) enqueue(task func()) error {
+	select {
+	case qs.queue <- task:
+		recordQueueLength(int64(len(qs.queue)))
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// stop closes the queue and blocks (or times out) waiting for in-flight and queued
+// items to drain.
+func (qs *queuedSender) stop(drainTimeout time.Duration) {
+	qs.stopOnce.Do(func() {
+		close(qs.queue)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		qs.wg.Wait()
+		close(done)
+	}()
+
+	if drainTimeout <= 0 {
+		<-done
+		return
+	}
+
+	select {
+	case <-done:
+	case <-time.After(drainTimeout):
+	}
+}
+
+// drainTimeout bounds how long Shutdown waits for the queue to drain.
+const drainTimeout = 10 * time.Second
+
+// newQueuedTraceSender wraps next with a bounded queue, returning the queueing
+// dataPusher and a Shutdown that drains the queue. If queueing is disabled, next is
+// returned unchanged along with a nil Shutdown.
+//
+// Queued work runs on a pool goroutine well after ConsumeTraceData has returned, so it
+// must not inherit the caller's (e.g. gRPC/HTTP handler) context: that context is
+// routinely cancelled the instant the handler returns, which would kill any retry the
+// queueing was meant to allow. Queued work instead runs with a detached context tagged
+// for the same exporterName, matching what ConsumeTraceData would have set up, and
+// carrying over the caller's exporterDestinationTagKey tag (set by a split exporter), if
+// any.
+func newQueuedTraceSender(exporterName string, queueSettings QueueSettings, next traceDataPusher) (traceDataPusher, Shutdown) {
+	if !queueSettings.Enabled {
+		return next, nil
+	}
+
+	qs := startQueuedSender(queueSettings)
+	detachedCtx := obsreport.ExporterContext(context.Background(), exporterName)
+	dataPusher := func(ctx context.Context, td consumerdata.TraceData) (int, error) {
+		taskCtx := detachedCtx
+		if dest, ok := tag.FromContext(ctx).Value(exporterDestinationTagKey); ok {
+			if taggedCtx, tagErr := tag.New(detachedCtx, tag.Upsert(exporterDestinationTagKey, dest)); tagErr == nil {
+				taskCtx = taggedCtx
+			}
+		}
+		err := qs.enqueue(func() {
+			_, _ = next(taskCtx, td)
+		})
+		if err != nil {
+			numSpans := len(td.Spans)
+			exporterCtx, span := obsreport.StartTraceDataExportOp(ctx, exporterName)
+			obsreport.EndTraceDataExportOp(exporterCtx, span, numSpans, numSpans, err)
+			return numSpans, err
+		}
+		return 0, nil
+	}
+
+	return dataPusher, func() error {
+		qs.stop(drainTimeout)
+		return nil
+	}
+}
+
+// newRetryMetricsSender is the metricsDataPusher equivalent of newRetryTraceSender.
+func newRetryMetricsSender(retrySettings RetrySettings, next metricsDataPusher) metricsDataPusher {
+	if !retrySettings.Enabled {
+		return next
+	}
+	return func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		return retryingSend(ctx, retrySettings, func() (int, error) {
+			return next(ctx, md)
+		})
+	}
+}
+
+// newRetryOTLPMetricsSender is the otlpMetricsDataPusher equivalent of newRetryTraceSender.
+func newRetryOTLPMetricsSender(retrySettings RetrySettings, next otlpMetricsDataPusher) otlpMetricsDataPusher {
+	if !retrySettings.Enabled {
+		return next
+	}
+	return func(ctx context.Context, md consumerdata.OTLPMetricsData) (int, error) {
+		return retryingSend(ctx, retrySettings, func() (int, error) {
+			return next(ctx, md)
+		})
+	}
+}
+
+// newQueuedMetricsSender is the metricsDataPusher equivalent of newQueuedTraceSender.
+func newQueuedMetricsSender(exporterName string, queueSettings QueueSettings, next metricsDataPusher) (metricsDataPusher, Shutdown) {
+	if !queueSettings.Enabled {
+		return next, nil
+	}
+
+	qs := startQueuedSender(queueSettings)
+	detachedCtx := obsreport.ExporterContext(context.Background(), exporterName)
+	dataPusher := func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		err := qs.enqueue(func() {
+			_, _ = next(detachedCtx, md)
+		})
+		if err != nil {
+			numTimeSeries := len(md.Metrics)
+			exporterCtx, span := obsreport.StartMetricsExportOp(ctx, exporterName)
+			obsreport.EndMetricsExportOp(exporterCtx, span, numTimeSeries, numTimeSeries, err)
+			return numTimeSeries, err
+		}
+		return 0, nil
+	}
+
+	return dataPusher, func() error {
+		qs.stop(drainTimeout)
+		return nil
+	}
+}
+
+// newQueuedOTLPMetricsSender is the otlpMetricsDataPusher equivalent of newQueuedTraceSender.
+func newQueuedOTLPMetricsSender(exporterName string, queueSettings QueueSettings, next otlpMetricsDataPusher) (otlpMetricsDataPusher, Shutdown) {
+	if !queueSettings.Enabled {
+		return next, nil
+	}
+
+	qs := startQueuedSender(queueSettings)
+	detachedCtx := obsreport.ExporterContext(context.Background(), exporterName)
+	dataPusher := func(ctx context.Context, md consumerdata.OTLPMetricsData) (int, error) {
+		err := qs.enqueue(func() {
+			_, _ = next(detachedCtx, md)
+		})
+		if err != nil {
+			numTimeSeries := md.MetricsCount()
+			exporterCtx, span := obsreport.StartMetricsExportOp(ctx, exporterName)
+			obsreport.EndMetricsExportOp(exporterCtx, span, numTimeSeries, numTimeSeries, err)
+			return numTimeSeries, err
+		}
+		return 0, nil
+	}
+
+	return dataPusher, func() error {
+		qs.stop(drainTimeout)
+		return nil
+	}
+}
+
+// newQueuedOTLPTraceSender is the otlpTraceDataPusher equivalent of newQueuedTraceSender.
+func newQueuedOTLPTraceSender(exporterName string, queueSettings QueueSettings, next otlpTraceDataPusher) (otlpTraceDataPusher, Shutdown) {
+	if !queueSettings.Enabled {
+		return next, nil
+	}
+
+	qs := startQueuedSender(queueSettings)
+	detachedCtx := obsreport.ExporterContext(context.Background(), exporterName)
+	dataPusher := func(ctx context.Context, td consumerdata.OTLPTraceData) (int, error) {
+		taskCtx := detachedCtx
+		if dest, ok := tag.FromContext(ctx).Value(exporterDestinationTagKey); ok {
+			if taggedCtx, tagErr := tag.New(detachedCtx, tag.Upsert(exporterDestinationTagKey, dest)); tagErr == nil {
+				taskCtx = taggedCtx
+			}
+		}
+		err := qs.enqueue(func() {
+			_, _ = next(taskCtx, td)
+		})
+		if err != nil {
+			numSpans := td.SpanCount()
+			exporterCtx, span := obsreport.StartTraceDataExportOp(ctx, exporterName)
+			obsreport.EndTraceDataExportOp(exporterCtx, span, numSpans, numSpans, err)
+			return numSpans, err
+		}
+		return 0, nil
+	}
+
+	return dataPusher, func() error {
+		qs.stop(drainTimeout)
+		return nil
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumererror"
+)
+
+func testRetrySettings() RetrySettings {
+	return RetrySettings{
+		Enabled:         true,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     5 * time.Millisecond,
+		MaxElapsedTime:  time.Second,
+		Multiplier:      1.5,
+	}
+}
+
+func TestRetryingSend_SucceedsAfterTransientFailures(t *testing.T) {
+	calls := 0
+	send := func() (int, error) {
+		calls++
+		if calls <= 2 {
+			return 1, errors.New("transient failure")
+		}
+		return 0, nil
+	}
+
+	dropped, err := retryingSend(context.Background(), testRetrySettings(), send)
+	if err != nil {
+		t.Fatalf("retryingSend() error = %v, want nil after retries succeed", err)
+	}
+	if dropped != 0 {
+		t.Errorf("dropped = %d, want 0 on the eventual success", dropped)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3 (2 failures + 1 success)", calls)
+	}
+}
+
+func TestRetryingSend_PermanentErrorNotRetried(t *testing.T) {
+	calls := 0
+	permanentErr := consumererror.NewPermanent(errors.New("bad batch"))
+	send := func() (int, error) {
+		calls++
+		return 5, permanentErr
+	}
+
+	dropped, err := retryingSend(context.Background(), testRetrySettings(), send)
+	if !errors.Is(err, permanentErr) {
+		t.Errorf("err = %v, want permanentErr", err)
+	}
+	if dropped != 5 {
+		t.Errorf("dropped = %d, want 5", dropped)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (permanent errors are never retried)", calls)
+	}
+}
+
+func TestRetryingSend_ContextCancelledStopsRetrying(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	send := func() (int, error) {
+		calls++
+		return 0, errors.New("transient failure")
+	}
+
+	retrySettings := testRetrySettings()
+	retrySettings.InitialInterval = time.Hour // would block forever if not for ctx cancellation
+
+	_, err := retryingSend(ctx, retrySettings, send)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (first attempt, then ctx.Done before the backoff sleep elapses)", calls)
+	}
+}
+
+func TestRetrySettings_NewBackOffUsesConfiguredInitialInterval(t *testing.T) {
+	retrySettings := RetrySettings{
+		Enabled:         true,
+		InitialInterval: 42 * time.Millisecond,
+		MaxInterval:     time.Second,
+		MaxElapsedTime:  time.Minute,
+		Multiplier:      2,
+	}
+
+	eb := retrySettings.newBackOff()
+	if eb.InitialInterval != retrySettings.InitialInterval {
+		t.Fatalf("InitialInterval = %v, want %v", eb.InitialInterval, retrySettings.InitialInterval)
+	}
+	if eb.Multiplier != retrySettings.Multiplier {
+		t.Fatalf("Multiplier = %v, want %v", eb.Multiplier, retrySettings.Multiplier)
+	}
+
+	// Reset() (called internally by newBackOff) is what seeds currentInterval from
+	// InitialInterval; NextBackOff's randomization factor defaults to 0.5, so the first
+	// delay must land within [0.5, 1.5] * InitialInterval. Before this fix, the delay
+	// was drawn from the library's hardcoded ~500ms default instead.
+	first := eb.NextBackOff()
+	minDelay := time.Duration(float64(retrySettings.InitialInterval) * 0.5)
+	maxDelay := time.Duration(float64(retrySettings.InitialInterval) * 1.5)
+	if first < minDelay || first > maxDelay {
+		t.Errorf("first backoff = %v, want within [%v, %v]", first, minDelay, maxDelay)
+	}
+}
+
+func TestQueuedSender_EnqueueReturnsErrQueueFullWhenAtCapacity(t *testing.T) {
+	qs := startQueuedSender(QueueSettings{NumConsumers: 0, QueueSize: 1})
+	defer qs.stop(0)
+
+	if err := qs.enqueue(func() {}); err != nil {
+		t.Fatalf("first enqueue() error = %v, want nil", err)
+	}
+	if err := qs.enqueue(func() {}); !errors.Is(err, errQueueFull) {
+		t.Errorf("second enqueue() error = %v, want errQueueFull", err)
+	}
+}
+
+func TestQueuedSender_DrainsQueuedWorkOnStop(t *testing.T) {
+	qs := startQueuedSender(QueueSettings{NumConsumers: 2, QueueSize: 10})
+
+	done := make(chan struct{}, 5)
+	for i := 0; i < 5; i++ {
+		if err := qs.enqueue(func() { done <- struct{}{} }); err != nil {
+			t.Fatalf("enqueue() error = %v", err)
+		}
+	}
+
+	qs.stop(time.Second)
+
+	if len(done) != 5 {
+		t.Errorf("completed tasks = %d, want 5 after stop drains the queue", len(done))
+	}
+}
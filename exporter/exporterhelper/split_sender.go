@@ -0,0 +1,327 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync/atomic"
+
+	"go.opencensus.io/tag"
+	"go.uber.org/multierr"
+
+	"github.com/open-telemetry/opentelemetry-collector/component"
+	"github.com/open-telemetry/opentelemetry-collector/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-collector/exporter"
+	"github.com/open-telemetry/opentelemetry-collector/obsreport"
+)
+
+// SplitRouter decides how to distribute a single TraceData batch across the
+// destination pushers given to NewSplitTraceExporter. It returns the sub-batches to
+// send and, for each sub-batch, the index of the destination pusher it belongs to.
+type SplitRouter interface {
+	Route(ctx context.Context, td consumerdata.TraceData) (subBatches []consumerdata.TraceData, destinations []int)
+}
+
+// RoundRobinRouter sends each incoming batch, in its entirety, to the next destination
+// in round-robin order.
+type RoundRobinRouter struct {
+	numDestinations int
+	next            uint64
+}
+
+// NewRoundRobinRouter creates a SplitRouter that cycles through numDestinations
+// pushers in order.
+func NewRoundRobinRouter(numDestinations int) *RoundRobinRouter {
+	return &RoundRobinRouter{numDestinations: numDestinations}
+}
+
+// Route implements SplitRouter.
+func (r *RoundRobinRouter) Route(_ context.Context, td consumerdata.TraceData) ([]consumerdata.TraceData, []int) {
+	idx := int(atomic.AddUint64(&r.next, 1) % uint64(r.numDestinations))
+	return []consumerdata.TraceData{td}, []int{idx}
+}
+
+// DuplicateRouter sends each incoming batch, in its entirety, to every destination.
+// Unlike RoundRobinRouter and ResourceAttributeRouter, which shard traffic across
+// destinations, DuplicateRouter is for fanning the same data out to multiple backends.
+type DuplicateRouter struct {
+	numDestinations int
+}
+
+// NewDuplicateRouter creates a SplitRouter that duplicates every batch to all
+// numDestinations pushers.
+func NewDuplicateRouter(numDestinations int) *DuplicateRouter {
+	return &DuplicateRouter{numDestinations: numDestinations}
+}
+
+// Route implements SplitRouter.
+func (r *DuplicateRouter) Route(_ context.Context, td consumerdata.TraceData) ([]consumerdata.TraceData, []int) {
+	subBatches := make([]consumerdata.TraceData, r.numDestinations)
+	destinations := make([]int, r.numDestinations)
+	for i := 0; i < r.numDestinations; i++ {
+		subBatches[i] = td
+		destinations[i] = i
+	}
+	return subBatches, destinations
+}
+
+// ResourceAttributeRouter sends each incoming batch, in its entirety, to the
+// destination obtained by hashing a named resource attribute (e.g. "service.name" or
+// "tenant.id"). Batches with no resource or no matching attribute are all routed to
+// the same destination (the hash of the empty string).
+type ResourceAttributeRouter struct {
+	AttributeKey    string
+	numDestinations int
+}
+
+// NewResourceAttributeRouter creates a SplitRouter that hashes attributeKey's value on
+// the batch's resource across numDestinations pushers.
+func NewResourceAttributeRouter(attributeKey string, numDestinations int) *ResourceAttributeRouter {
+	return &ResourceAttributeRouter{AttributeKey: attributeKey, numDestinations: numDestinations}
+}
+
+// Route implements SplitRouter.
+func (r *ResourceAttributeRouter) Route(_ context.Context, td consumerdata.TraceData) ([]consumerdata.TraceData, []int) {
+	var value string
+	if td.Resource != nil {
+		value = td.Resource.Labels[r.AttributeKey]
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(value))
+	idx := int(h.Sum32() % uint32(r.numDestinations))
+	return []consumerdata.TraceData{td}, []int{idx}
+}
+
+// destinationContext tags ctx with destIdx under exporterDestinationTagKey, so that
+// per-destination drop/retry counts can be broken out without folding the destination
+// index into the exporter name itself (which would change the identity and cardinality
+// of the "exporter" tag that obsreport and every downstream dashboard key off of).
+func destinationContext(ctx context.Context, destIdx int) context.Context {
+	taggedCtx, err := tag.New(ctx, tag.Upsert(exporterDestinationTagKey, strconv.Itoa(destIdx)))
+	if err != nil {
+		return ctx
+	}
+	return taggedCtx
+}
+
+// splitTraceExporter fans a single traceDataPusher call out to one or more of several
+// destination pushers, as decided by a SplitRouter.
+type splitTraceExporter struct {
+	exporterFullName string
+	pushers          []traceDataPusher
+	router           SplitRouter
+	shutdown         Shutdown
+}
+
+var _ exporter.TraceExporter = (*splitTraceExporter)(nil)
+
+func (se *splitTraceExporter) Start(host component.Host) error {
+	return nil
+}
+
+func (se *splitTraceExporter) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	exporterCtx := obsreport.ExporterContext(ctx, se.exporterFullName)
+	subBatches, destinations := se.router.Route(exporterCtx, td)
+
+	var errs error
+	for i, sub := range subBatches {
+		destIdx := destinations[i]
+		if destIdx < 0 || destIdx >= len(se.pushers) {
+			errs = multierr.Append(errs, fmt.Errorf("split router returned out-of-range destination %d", destIdx))
+			continue
+		}
+		if _, err := se.pushers[destIdx](destinationContext(exporterCtx, destIdx), sub); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Shutdown stops the exporter and is invoked during shutdown.
+func (se *splitTraceExporter) Shutdown() error {
+	return se.shutdown()
+}
+
+// NewSplitTraceExporter creates a TraceExporter that routes each incoming batch to one
+// or more of pushers, as decided by router. Every pusher shares config.Name() as its obsreport
+// exporter identity (so the "exporter" tag's cardinality is unaffected by the number of
+// destinations), with the destination distinguished via the exporterDestinationTagKey
+// tag set by destinationContext. If WithRetry/WithQueue are passed, each pusher also gets
+// its own retry and queue sender, so a failure on one destination only causes that
+// destination's sub-batch to be retried.
+func NewSplitTraceExporter(
+	config configmodels.Exporter,
+	pushers []traceDataPusher,
+	router SplitRouter,
+	options ...ExporterOption,
+) (exporter.TraceExporter, error) {
+
+	if config == nil {
+		return nil, errNilConfig
+	}
+
+	if len(pushers) == 0 {
+		return nil, errNoPushers
+	}
+
+	if router == nil {
+		return nil, errNilSplitRouter
+	}
+
+	opts := newExporterOptions(options...)
+
+	shutdown := opts.shutdown
+	if shutdown == nil {
+		shutdown = func() error {
+			return nil
+		}
+	}
+	shutdowns := []Shutdown{shutdown}
+
+	wrapped := make([]traceDataPusher, len(pushers))
+	for i, p := range pushers {
+		dataPusher := p.withObservability(config.Name())
+		dataPusher = newRetryTraceSender(opts.retrySettings, dataPusher)
+		dataPusher, queueShutdown := newQueuedTraceSender(config.Name(), opts.queueSettings, dataPusher)
+		if queueShutdown != nil {
+			shutdowns = append(shutdowns, queueShutdown)
+		}
+
+		wrapped[i] = dataPusher
+	}
+
+	return &splitTraceExporter{
+		exporterFullName: config.Name(),
+		pushers:          wrapped,
+		router:           router,
+		shutdown:         combineShutdown(shutdowns...),
+	}, nil
+}
+
+// OTLPSplitRouter is the otlpTraceDataPusher equivalent of SplitRouter.
+type OTLPSplitRouter interface {
+	Route(ctx context.Context, td consumerdata.OTLPTraceData) (subBatches []consumerdata.OTLPTraceData, destinations []int)
+}
+
+// OTLPRoundRobinRouter is the OTLPSplitRouter equivalent of RoundRobinRouter.
+type OTLPRoundRobinRouter struct {
+	numDestinations int
+	next            uint64
+}
+
+// NewOTLPRoundRobinRouter creates an OTLPSplitRouter that cycles through
+// numDestinations pushers in order.
+func NewOTLPRoundRobinRouter(numDestinations int) *OTLPRoundRobinRouter {
+	return &OTLPRoundRobinRouter{numDestinations: numDestinations}
+}
+
+// Route implements OTLPSplitRouter.
+func (r *OTLPRoundRobinRouter) Route(_ context.Context, td consumerdata.OTLPTraceData) ([]consumerdata.OTLPTraceData, []int) {
+	idx := int(atomic.AddUint64(&r.next, 1) % uint64(r.numDestinations))
+	return []consumerdata.OTLPTraceData{td}, []int{idx}
+}
+
+// splitOTLPTraceExporter is the otlpTraceDataPusher equivalent of splitTraceExporter.
+type splitOTLPTraceExporter struct {
+	exporterFullName string
+	pushers          []otlpTraceDataPusher
+	router           OTLPSplitRouter
+	shutdown         Shutdown
+}
+
+var _ exporter.OTLPTraceExporter = (*splitOTLPTraceExporter)(nil)
+
+func (se *splitOTLPTraceExporter) Start(host component.Host) error {
+	return nil
+}
+
+func (se *splitOTLPTraceExporter) ConsumeOTLPTrace(ctx context.Context, td consumerdata.OTLPTraceData) error {
+	exporterCtx := obsreport.ExporterContext(ctx, se.exporterFullName)
+	subBatches, destinations := se.router.Route(exporterCtx, td)
+
+	var errs error
+	for i, sub := range subBatches {
+		destIdx := destinations[i]
+		if destIdx < 0 || destIdx >= len(se.pushers) {
+			errs = multierr.Append(errs, fmt.Errorf("split router returned out-of-range destination %d", destIdx))
+			continue
+		}
+		if _, err := se.pushers[destIdx](destinationContext(exporterCtx, destIdx), sub); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Shutdown stops the exporter and is invoked during shutdown.
+func (se *splitOTLPTraceExporter) Shutdown() error {
+	return se.shutdown()
+}
+
+// NewSplitOTLPTraceExporter is the otlpTraceDataPusher equivalent of
+// NewSplitTraceExporter.
+func NewSplitOTLPTraceExporter(
+	config configmodels.Exporter,
+	pushers []otlpTraceDataPusher,
+	router OTLPSplitRouter,
+	options ...ExporterOption,
+) (exporter.OTLPTraceExporter, error) {
+
+	if config == nil {
+		return nil, errNilConfig
+	}
+
+	if len(pushers) == 0 {
+		return nil, errNoPushers
+	}
+
+	if router == nil {
+		return nil, errNilSplitRouter
+	}
+
+	opts := newExporterOptions(options...)
+
+	shutdown := opts.shutdown
+	if shutdown == nil {
+		shutdown = func() error {
+			return nil
+		}
+	}
+	shutdowns := []Shutdown{shutdown}
+
+	wrapped := make([]otlpTraceDataPusher, len(pushers))
+	for i, p := range pushers {
+		dataPusher := p.withObservability(config.Name())
+		dataPusher = newRetryOTLPTraceSender(opts.retrySettings, dataPusher)
+		dataPusher, queueShutdown := newQueuedOTLPTraceSender(config.Name(), opts.queueSettings, dataPusher)
+		if queueShutdown != nil {
+			shutdowns = append(shutdowns, queueShutdown)
+		}
+
+		wrapped[i] = dataPusher
+	}
+
+	return &splitOTLPTraceExporter{
+		exporterFullName: config.Name(),
+		pushers:          wrapped,
+		router:           router,
+		shutdown:         combineShutdown(shutdowns...),
+	}, nil
+}
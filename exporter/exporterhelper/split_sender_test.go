@@ -0,0 +1,156 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumerdata"
+)
+
+func TestRoundRobinRouter_CyclesThroughDestinations(t *testing.T) {
+	router := NewRoundRobinRouter(3)
+
+	var got []int
+	for i := 0; i < 6; i++ {
+		_, destinations := router.Route(context.Background(), consumerdata.TraceData{})
+		if len(destinations) != 1 {
+			t.Fatalf("destinations = %v, want exactly 1 per call", destinations)
+		}
+		got = append(got, destinations[0])
+	}
+
+	want := []int{1, 2, 0, 1, 2, 0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("call %d: destination = %d, want %d (got sequence %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestDuplicateRouter_RoutesToAllDestinations(t *testing.T) {
+	router := NewDuplicateRouter(3)
+
+	td := consumerdata.TraceData{}
+	subBatches, destinations := router.Route(context.Background(), td)
+
+	if len(subBatches) != 3 || len(destinations) != 3 {
+		t.Fatalf("got %d subBatches and %d destinations, want 3 of each", len(subBatches), len(destinations))
+	}
+	for i := 0; i < 3; i++ {
+		if destinations[i] != i {
+			t.Errorf("destinations[%d] = %d, want %d", i, destinations[i], i)
+		}
+	}
+}
+
+func TestResourceAttributeRouter_HashesConsistently(t *testing.T) {
+	router := NewResourceAttributeRouter("service.name", 4)
+
+	_, firstDestinations := router.Route(context.Background(), consumerdata.TraceData{})
+	_, secondDestinations := router.Route(context.Background(), consumerdata.TraceData{})
+
+	if len(firstDestinations) != 1 || len(secondDestinations) != 1 {
+		t.Fatalf("want exactly 1 destination per call, got %v and %v", firstDestinations, secondDestinations)
+	}
+	if firstDestinations[0] != secondDestinations[0] {
+		t.Errorf("routing the same (empty) resource value twice gave different destinations: %d vs %d", firstDestinations[0], secondDestinations[0])
+	}
+	if firstDestinations[0] < 0 || firstDestinations[0] >= 4 {
+		t.Errorf("destination = %d, want within [0, 4)", firstDestinations[0])
+	}
+}
+
+func TestResourceAttributeRouter_SingleDestinationAlwaysZero(t *testing.T) {
+	router := NewResourceAttributeRouter("service.name", 1)
+
+	_, destinations := router.Route(context.Background(), consumerdata.TraceData{})
+	if len(destinations) != 1 || destinations[0] != 0 {
+		t.Errorf("destinations = %v, want [0]", destinations)
+	}
+}
+
+func TestSplitTraceExporter_ConsumeTraceData_AggregatesErrorsAcrossDestinations(t *testing.T) {
+	errA := errors.New("destination a unavailable")
+	errB := errors.New("destination b unavailable")
+
+	pushers := []traceDataPusher{
+		func(ctx context.Context, td consumerdata.TraceData) (int, error) { return 1, errA },
+		func(ctx context.Context, td consumerdata.TraceData) (int, error) { return 1, errB },
+	}
+
+	se := &splitTraceExporter{
+		exporterFullName: "fake",
+		pushers:          pushers,
+		router:           NewDuplicateRouter(2),
+		shutdown:         func() error { return nil },
+	}
+
+	err := se.ConsumeTraceData(context.Background(), consumerdata.TraceData{})
+	if !errors.Is(err, errA) {
+		t.Errorf("err = %v, want it to wrap errA", err)
+	}
+	if !errors.Is(err, errB) {
+		t.Errorf("err = %v, want it to wrap errB", err)
+	}
+}
+
+func TestSplitTraceExporter_ConsumeTraceData_OutOfRangeDestination(t *testing.T) {
+	calls := 0
+	pushers := []traceDataPusher{
+		func(ctx context.Context, td consumerdata.TraceData) (int, error) { calls++; return 0, nil },
+	}
+
+	se := &splitTraceExporter{
+		exporterFullName: "fake",
+		pushers:          pushers,
+		router:           NewRoundRobinRouter(5), // reports indices the single pusher slice can't satisfy
+		shutdown:         func() error { return nil },
+	}
+
+	err := se.ConsumeTraceData(context.Background(), consumerdata.TraceData{})
+	if err == nil {
+		t.Fatal("ConsumeTraceData() error = nil, want an out-of-range error")
+	}
+	if calls != 0 {
+		t.Errorf("pusher calls = %d, want 0 (out-of-range destination must not be invoked)", calls)
+	}
+}
+
+func TestSplitOTLPTraceExporter_ConsumeOTLPTrace_AggregatesErrorsAcrossDestinations(t *testing.T) {
+	errA := errors.New("destination a unavailable")
+
+	pushers := []otlpTraceDataPusher{
+		func(ctx context.Context, td consumerdata.OTLPTraceData) (int, error) { return 1, errA },
+		func(ctx context.Context, td consumerdata.OTLPTraceData) (int, error) { return 0, nil },
+	}
+
+	se := &splitOTLPTraceExporter{
+		exporterFullName: "fake",
+		pushers:          pushers,
+		router:           NewOTLPRoundRobinRouter(2),
+		shutdown:         func() error { return nil },
+	}
+
+	// The first call lands on destination index 1 (no error); the second wraps around
+	// to destination 0 (errA).
+	_ = se.ConsumeOTLPTrace(context.Background(), consumerdata.OTLPTraceData{})
+	err := se.ConsumeOTLPTrace(context.Background(), consumerdata.OTLPTraceData{})
+	if !errors.Is(err, errA) {
+		t.Errorf("err = %v, want it to wrap errA", err)
+	}
+}
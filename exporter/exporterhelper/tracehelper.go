@@ -76,18 +76,24 @@ func NewTraceExporter(
 	opts := newExporterOptions(options...)
 
 	dataPusher = dataPusher.withObservability(config.Name())
+	dataPusher = newRetryTraceSender(opts.retrySettings, dataPusher)
+	dataPusher, queueShutdown := newQueuedTraceSender(config.Name(), opts.queueSettings, dataPusher)
 
 	// The default shutdown function does nothing.
-	if opts.shutdown == nil {
-		opts.shutdown = func() error {
+	shutdown := opts.shutdown
+	if shutdown == nil {
+		shutdown = func() error {
 			return nil
 		}
 	}
+	if queueShutdown != nil {
+		shutdown = combineShutdown(queueShutdown, shutdown)
+	}
 
 	return &traceExporter{
 		exporterFullName: config.Name(),
 		dataPusher:       dataPusher,
-		shutdown:         opts.shutdown,
+		shutdown:         shutdown,
 	}, nil
 }
 
@@ -153,18 +159,24 @@ func NewOTLPTraceExporter(
 	opts := newExporterOptions(options...)
 
 	dataPusher = dataPusher.withObservability(config.Name())
+	dataPusher = newRetryOTLPTraceSender(opts.retrySettings, dataPusher)
+	dataPusher, queueShutdown := newQueuedOTLPTraceSender(config.Name(), opts.queueSettings, dataPusher)
 
 	// The default shutdown function does nothing.
-	if opts.shutdown == nil {
-		opts.shutdown = func() error {
+	shutdown := opts.shutdown
+	if shutdown == nil {
+		shutdown = func() error {
 			return nil
 		}
 	}
+	if queueShutdown != nil {
+		shutdown = combineShutdown(queueShutdown, shutdown)
+	}
 
 	return &otlpTraceExporter{
 		exporterFullName: config.Name(),
 		dataPusher:       dataPusher,
-		shutdown:         opts.shutdown,
+		shutdown:         shutdown,
 	}, nil
 }
 
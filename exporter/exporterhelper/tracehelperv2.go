@@ -0,0 +1,221 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"fmt"
+
+	"go.opencensus.io/trace"
+
+	"github.com/open-telemetry/opentelemetry-collector/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumererror"
+	"github.com/open-telemetry/opentelemetry-collector/exporter"
+	"github.com/open-telemetry/opentelemetry-collector/obsreport"
+)
+
+// ExportResult carries the outcome of a single export attempt, including the partial
+// success information that some backends (e.g. OTLP) can return alongside a nominally
+// successful response.
+type ExportResult struct {
+	// Accepted is the number of items the destination reported as successfully received.
+	Accepted int
+	// Rejected is the number of items the destination reported as rejected or dropped.
+	Rejected int
+	// PartialMessage is the human-readable message returned alongside a partial success
+	// response. Empty if the destination did not report a partial success.
+	PartialMessage string
+	// Err is the error (if any) for the export attempt as a whole.
+	Err error
+}
+
+// PartialSuccessError indicates that a destination accepted part of a batch and
+// rejected the rest, explaining why in Message. It is never retried: the accepted
+// portion is already delivered and the rejected portion is, by definition, not
+// transient.
+type PartialSuccessError struct {
+	Rejected int
+	Message  string
+}
+
+var _ error = (*PartialSuccessError)(nil)
+
+func (e *PartialSuccessError) Error() string {
+	return fmt.Sprintf("partial success: %d items rejected: %s", e.Rejected, e.Message)
+}
+
+// traceDataPusherV2 is the richer equivalent of traceDataPusher: instead of returning
+// just a dropped-span count it returns an ExportResult, which can additionally surface
+// partial-success information from the destination.
+type traceDataPusherV2 func(ctx context.Context, td consumerdata.TraceData) ExportResult
+
+// otlpTraceDataPusherV2 is the richer equivalent of otlpTraceDataPusher.
+type otlpTraceDataPusherV2 func(ctx context.Context, td consumerdata.OTLPTraceData) ExportResult
+
+// withObservability wraps the current pusher into a function that records the
+// observability signals during the pusher execution, including partial-success drops.
+func (p traceDataPusherV2) withObservability(exporterName string) traceDataPusherV2 {
+	return func(ctx context.Context, td consumerdata.TraceData) ExportResult {
+		exporterCtx, span := obsreport.StartTraceDataExportOp(ctx, exporterName)
+		result := p(exporterCtx, td)
+		recordPartialSuccess(exporterCtx, span, &result)
+
+		numSpans := len(td.Spans)
+		obsreport.EndTraceDataExportOp(exporterCtx, span, numSpans, result.Rejected, result.Err)
+		return result
+	}
+}
+
+// withObservability wraps the current pusher into a function that records the
+// observability signals during the pusher execution, including partial-success drops.
+func (p otlpTraceDataPusherV2) withObservability(exporterName string) otlpTraceDataPusherV2 {
+	return func(ctx context.Context, td consumerdata.OTLPTraceData) ExportResult {
+		exporterCtx, span := obsreport.StartTraceDataExportOp(ctx, exporterName)
+		result := p(exporterCtx, td)
+		recordPartialSuccess(exporterCtx, span, &result)
+
+		numSpans := td.SpanCount()
+		obsreport.EndTraceDataExportOp(exporterCtx, span, numSpans, result.Rejected, result.Err)
+		return result
+	}
+}
+
+// recordPartialSuccess annotates the export span and bumps the partial-success drop
+// counter when result carries a partial-success message from the destination. It also
+// fills in result.Err with a *PartialSuccessError if the pusher did not already set one,
+// so downstream senders (e.g. queued-retry) know not to retry the rejected portion.
+// result is taken by pointer so that fill-in is visible to the caller. ctx is passed
+// through to the stats recording call so an exporterDestinationTagKey tag set by a
+// split/fan-out exporter is preserved.
+func recordPartialSuccess(ctx context.Context, span *trace.Span, result *ExportResult) {
+	if result.PartialMessage == "" {
+		return
+	}
+
+	span.Annotate([]trace.Attribute{
+		trace.Int64Attribute("rejected", int64(result.Rejected)),
+		trace.StringAttribute("message", result.PartialMessage),
+	}, "partial_success")
+
+	recordPartialSuccessDroppedSpans(ctx, int64(result.Rejected))
+
+	if result.Err == nil {
+		result.Err = &PartialSuccessError{Rejected: result.Rejected, Message: result.PartialMessage}
+	}
+}
+
+// NewTraceExporterV2 creates a TraceExporter from a traceDataPusherV2, giving exporters
+// access to partial-success reporting via ExportResult. It otherwise behaves exactly
+// like NewTraceExporter, including support for WithRetry/WithQueue.
+func NewTraceExporterV2(
+	config configmodels.Exporter,
+	dataPusher traceDataPusherV2,
+	options ...ExporterOption,
+) (exporter.TraceExporter, error) {
+
+	if config == nil {
+		return nil, errNilConfig
+	}
+
+	if dataPusher == nil {
+		return nil, errNilPushTraceData
+	}
+
+	opts := newExporterOptions(options...)
+
+	observedPusher := dataPusher.withObservability(config.Name())
+	adaptedPusher := traceDataPusher(func(ctx context.Context, td consumerdata.TraceData) (int, error) {
+		result := observedPusher(ctx, td)
+		return result.Rejected, asRetryableErr(result.Err)
+	})
+	adaptedPusher = newRetryTraceSender(opts.retrySettings, adaptedPusher)
+	adaptedPusher, queueShutdown := newQueuedTraceSender(config.Name(), opts.queueSettings, adaptedPusher)
+
+	// The default shutdown function does nothing.
+	shutdown := opts.shutdown
+	if shutdown == nil {
+		shutdown = func() error {
+			return nil
+		}
+	}
+	if queueShutdown != nil {
+		shutdown = combineShutdown(queueShutdown, shutdown)
+	}
+
+	return &traceExporter{
+		exporterFullName: config.Name(),
+		dataPusher:       adaptedPusher,
+		shutdown:         shutdown,
+	}, nil
+}
+
+// NewOTLPTraceExporterV2 creates an OTLPTraceExporter from an otlpTraceDataPusherV2,
+// giving OTLP exporters access to partial-success reporting via ExportResult. It
+// otherwise behaves exactly like NewOTLPTraceExporter, including support for
+// WithRetry/WithQueue.
+func NewOTLPTraceExporterV2(
+	config configmodels.Exporter,
+	dataPusher otlpTraceDataPusherV2,
+	options ...ExporterOption,
+) (exporter.OTLPTraceExporter, error) {
+
+	if config == nil {
+		return nil, errNilConfig
+	}
+
+	if dataPusher == nil {
+		return nil, errNilPushTraceData
+	}
+
+	opts := newExporterOptions(options...)
+
+	observedPusher := dataPusher.withObservability(config.Name())
+	adaptedPusher := otlpTraceDataPusher(func(ctx context.Context, td consumerdata.OTLPTraceData) (int, error) {
+		result := observedPusher(ctx, td)
+		return result.Rejected, asRetryableErr(result.Err)
+	})
+	adaptedPusher = newRetryOTLPTraceSender(opts.retrySettings, adaptedPusher)
+	adaptedPusher, queueShutdown := newQueuedOTLPTraceSender(config.Name(), opts.queueSettings, adaptedPusher)
+
+	// The default shutdown function does nothing.
+	shutdown := opts.shutdown
+	if shutdown == nil {
+		shutdown = func() error {
+			return nil
+		}
+	}
+	if queueShutdown != nil {
+		shutdown = combineShutdown(queueShutdown, shutdown)
+	}
+
+	return &otlpTraceExporter{
+		exporterFullName: config.Name(),
+		dataPusher:       adaptedPusher,
+		shutdown:         shutdown,
+	}, nil
+}
+
+// asRetryableErr marks a *PartialSuccessError as permanent so the queued-retry sender
+// never replays a batch whose accepted portion was already delivered.
+func asRetryableErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*PartialSuccessError); ok {
+		return consumererror.NewPermanent(err)
+	}
+	return err
+}
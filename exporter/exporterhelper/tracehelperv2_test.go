@@ -0,0 +1,124 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-collector/consumer/consumererror"
+)
+
+func testRetrySettingsV2() RetrySettings {
+	return RetrySettings{
+		Enabled:         true,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		MaxElapsedTime:  time.Second,
+		Multiplier:      2,
+	}
+}
+
+func TestRecordPartialSuccess_FillsInErrWhenPusherLeftItNil(t *testing.T) {
+	_, span := trace.StartSpan(context.Background(), "test")
+	defer span.End()
+
+	result := ExportResult{Rejected: 3, PartialMessage: "bad batch"}
+	recordPartialSuccess(context.Background(), span, &result)
+
+	pse, ok := result.Err.(*PartialSuccessError)
+	if !ok {
+		t.Fatalf("result.Err = %v (%T), want *PartialSuccessError", result.Err, result.Err)
+	}
+	if pse.Rejected != 3 {
+		t.Errorf("pse.Rejected = %d, want 3", pse.Rejected)
+	}
+}
+
+func TestRecordPartialSuccess_LeavesExistingErrUntouched(t *testing.T) {
+	_, span := trace.StartSpan(context.Background(), "test")
+	defer span.End()
+
+	originalErr := errors.New("destination-specific failure")
+	result := ExportResult{Rejected: 2, PartialMessage: "bad batch", Err: originalErr}
+	recordPartialSuccess(context.Background(), span, &result)
+
+	if result.Err != originalErr {
+		t.Errorf("result.Err = %v, want untouched originalErr %v", result.Err, originalErr)
+	}
+}
+
+func TestNewTraceExporterV2_PartialSuccessIsNotRetried(t *testing.T) {
+	calls := 0
+	pusher := traceDataPusherV2(func(ctx context.Context, td consumerdata.TraceData) ExportResult {
+		calls++
+		return ExportResult{Accepted: 1, Rejected: 1, PartialMessage: "one item invalid"}
+	})
+
+	exp, err := NewTraceExporterV2(fakeExporterConfig("fake"), pusher, WithRetry(testRetrySettingsV2()))
+	if err != nil {
+		t.Fatalf("NewTraceExporterV2() error = %v", err)
+	}
+
+	consumeErr := exp.ConsumeTraceData(context.Background(), consumerdata.TraceData{})
+	if consumeErr == nil {
+		t.Fatal("ConsumeTraceData() error = nil, want a permanent partial-success error")
+	}
+
+	perm, ok := consumeErr.(consumererror.Permanent)
+	if !ok {
+		t.Fatalf("error = %v (%T), want consumererror.Permanent", consumeErr, consumeErr)
+	}
+	if _, ok := perm.Err.(*PartialSuccessError); !ok {
+		t.Errorf("perm.Err = %v (%T), want *PartialSuccessError", perm.Err, perm.Err)
+	}
+	if calls != 1 {
+		t.Errorf("pusher calls = %d, want 1 (a permanent error must not be retried)", calls)
+	}
+}
+
+func TestNewOTLPTraceExporterV2_PartialSuccessIsNotRetried(t *testing.T) {
+	calls := 0
+	pusher := otlpTraceDataPusherV2(func(ctx context.Context, td consumerdata.OTLPTraceData) ExportResult {
+		calls++
+		return ExportResult{Accepted: 1, Rejected: 1, PartialMessage: "one item invalid"}
+	})
+
+	exp, err := NewOTLPTraceExporterV2(fakeExporterConfig("fake"), pusher, WithRetry(testRetrySettingsV2()))
+	if err != nil {
+		t.Fatalf("NewOTLPTraceExporterV2() error = %v", err)
+	}
+
+	consumeErr := exp.ConsumeOTLPTrace(context.Background(), consumerdata.OTLPTraceData{})
+	if consumeErr == nil {
+		t.Fatal("ConsumeOTLPTrace() error = nil, want a permanent partial-success error")
+	}
+
+	perm, ok := consumeErr.(consumererror.Permanent)
+	if !ok {
+		t.Fatalf("error = %v (%T), want consumererror.Permanent", consumeErr, consumeErr)
+	}
+	if _, ok := perm.Err.(*PartialSuccessError); !ok {
+		t.Errorf("perm.Err = %v (%T), want *PartialSuccessError", perm.Err, perm.Err)
+	}
+	if calls != 1 {
+		t.Errorf("pusher calls = %d, want 1 (a permanent error must not be retried)", calls)
+	}
+}